@@ -0,0 +1,179 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Severity is how serious a ConfigIssue is. A warning is reported but
+// does not fail a check; an error does.
+type Severity string
+
+const (
+	// SeverityError marks an issue that should fail a config check.
+	SeverityError Severity = "error"
+	// SeverityWarning marks an issue worth surfacing but not fatal.
+	SeverityWarning Severity = "warning"
+)
+
+// ConfigIssue describes one problem found by Conf.Check, such as a missing
+// required field, a file reference that doesn't exist on disk, or two
+// environments fighting over the same theme in the same store.
+type ConfigIssue struct {
+	Severity Severity
+	Env      string
+	Field    string
+	Message  string
+}
+
+// String renders a ConfigIssue as a single line for a CLI report.
+func (i ConfigIssue) String() string {
+	if i.Env == "" {
+		return fmt.Sprintf("[%s] %s", i.Severity, i.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s: %s", i.Severity, i.Env, i.Field, i.Message)
+}
+
+// Check validates c without touching Shopify: that the file it was loaded
+// from exists, that every environment defines its required fields once
+// osEnv (e.g. THEMEKIT_PASSWORD) is mixed in the same way Get/Set do, that
+// files it references (directory, ignore_files) exist on disk, and that
+// no two environments collide on theme_id within the same store.
+func (c Conf) Check() []ConfigIssue {
+	var issues []ConfigIssue
+
+	if c.path != "" {
+		if _, _, err := searchConfigPath(c.path); err != nil {
+			issues = append(issues, ConfigIssue{
+				Severity: SeverityError,
+				Field:    "path",
+				Message:  fmt.Sprintf("no config file found at %s", c.path),
+			})
+		}
+	}
+
+	names := make([]string, 0, len(c.Envs))
+	for name := range c.Envs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	themeIDsByStore := map[string]map[string]string{}
+
+	for _, name := range names {
+		e := c.Envs[name]
+		if e == nil {
+			issues = append(issues, ConfigIssue{Severity: SeverityError, Env: name, Message: "environment is defined but empty"})
+			continue
+		}
+		merged, _ := newEnv(name, *e, c.osEnv)
+		issues = append(issues, checkEnv(name, *merged)...)
+		issues = append(issues, checkThemeCollision(name, *merged, themeIDsByStore)...)
+	}
+
+	return issues
+}
+
+func checkEnv(name string, e Env) []ConfigIssue {
+	var issues []ConfigIssue
+
+	for _, field := range missingRequiredFields(e) {
+		issues = append(issues, ConfigIssue{
+			Severity: SeverityError,
+			Env:      name,
+			Field:    field,
+			Message:  "required field is not set",
+		})
+	}
+
+	if e.Directory != "" {
+		if _, err := os.Stat(e.Directory); err != nil {
+			issues = append(issues, ConfigIssue{
+				Severity: SeverityError,
+				Env:      name,
+				Field:    "directory",
+				Message:  fmt.Sprintf("%s does not exist", e.Directory),
+			})
+		}
+	}
+
+	for _, ignored := range e.IgnoreFiles {
+		if strings.ContainsAny(ignored, "*?[") {
+			continue // glob pattern, not a literal path to stat
+		}
+		if _, err := os.Stat(ignored); err != nil {
+			issues = append(issues, ConfigIssue{
+				Severity: SeverityWarning,
+				Env:      name,
+				Field:    "ignore_files",
+				Message:  fmt.Sprintf("%s does not exist", ignored),
+			})
+		}
+	}
+
+	return issues
+}
+
+func checkThemeCollision(name string, e Env, themeIDsByStore map[string]map[string]string) []ConfigIssue {
+	if e.Store == "" || e.ThemeID == "" {
+		return nil
+	}
+
+	byTheme, ok := themeIDsByStore[e.Store]
+	if !ok {
+		themeIDsByStore[e.Store] = map[string]string{e.ThemeID: name}
+		return nil
+	}
+
+	other, collides := byTheme[e.ThemeID]
+	if !collides {
+		byTheme[e.ThemeID] = name
+		return nil
+	}
+
+	return []ConfigIssue{{
+		Severity: SeverityError,
+		Env:      name,
+		Field:    "theme_id",
+		Message:  fmt.Sprintf("theme %s on store %s is also used by environment %q", e.ThemeID, e.Store, other),
+	}}
+}
+
+// CheckConfig loads configPath and runs Conf.Check over the result,
+// printing a human-readable report to out. It returns false if loading
+// failed or any issue found was an error, so it can drive the process
+// exit code for a themekit check-config subcommand. It loads with
+// Interpolate: true so that a ${VAR}-style reference in a string field
+// such as directory is resolved before Check stats it on disk rather than
+// being reported as a literal, nonexistent "${VAR}" path (ignore_files
+// entries are not interpolated and are stat'd as written); a config that
+// relies on a ${VAR:?err} required variable not being set in the
+// checking environment is reported as a load error. It loads through
+// loadConf rather than Load so that a required field supplied only via
+// osEnv (e.g. THEMEKIT_PASSWORD) reaches Check's osEnv-aware report
+// instead of failing on Load's plain, osEnv-blind validate.
+func CheckConfig(configPath string, out io.Writer) bool {
+	conf, err := loadConf(configPath, LoadOptions{Interpolate: true})
+	if err != nil {
+		fmt.Fprintf(out, "error: %v\n", err)
+		return false
+	}
+
+	issues := conf.Check()
+	if len(issues) == 0 {
+		fmt.Fprintln(out, "config OK")
+		return true
+	}
+
+	ok := true
+	for _, issue := range issues {
+		fmt.Fprintln(out, issue.String())
+		if issue.Severity == SeverityError {
+			ok = false
+		}
+	}
+	return ok
+}
@@ -0,0 +1,191 @@
+package env
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckPasses(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themekit-check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := New(filepath.Join(dir, "config.yml"))
+	if _, err := conf.Set("production", Env{
+		Store:     "shop.myshopify.com",
+		Password:  "pw",
+		ThemeID:   "123",
+		Directory: dir,
+	}); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if err := conf.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	loaded, err := Load(conf.path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if issues := loaded.Check(); len(issues) != 0 {
+		t.Errorf("expected a valid config to have no issues, got %v", issues)
+	}
+}
+
+func TestCheckMissingRequiredField(t *testing.T) {
+	conf := Conf{Envs: map[string]*Env{
+		"production": {Env: "production", Store: "shop.myshopify.com", ThemeID: "123"},
+	}}
+
+	issues := conf.Check()
+	found := false
+	for _, issue := range issues {
+		if issue.Severity == SeverityError && issue.Env == "production" && issue.Field == "password" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected a missing required password to be reported, got %v", issues)
+	}
+}
+
+func TestCheckMissingDirectoryAndIgnoreFiles(t *testing.T) {
+	conf := Conf{Envs: map[string]*Env{
+		"production": {
+			Env:         "production",
+			Store:       "shop.myshopify.com",
+			Password:    "pw",
+			ThemeID:     "123",
+			Directory:   "/no/such/directory",
+			IgnoreFiles: []string{"/no/such/ignore-file"},
+		},
+	}}
+
+	issues := conf.Check()
+
+	var gotDirectory, gotIgnore bool
+	for _, issue := range issues {
+		switch issue.Field {
+		case "directory":
+			gotDirectory = issue.Severity == SeverityError
+		case "ignore_files":
+			gotIgnore = issue.Severity == SeverityWarning
+		}
+	}
+	if !gotDirectory {
+		t.Errorf("expected a missing directory to be reported as an error, got %v", issues)
+	}
+	if !gotIgnore {
+		t.Errorf("expected a missing ignore_files entry to be reported as a warning, got %v", issues)
+	}
+}
+
+func TestCheckIgnoreFilesSkipsGlobs(t *testing.T) {
+	conf := Conf{Envs: map[string]*Env{
+		"production": {
+			Env:         "production",
+			Store:       "shop.myshopify.com",
+			Password:    "pw",
+			ThemeID:     "123",
+			Directory:   ".",
+			IgnoreFiles: []string{"*.tmp"},
+		},
+	}}
+
+	for _, issue := range conf.Check() {
+		if issue.Field == "ignore_files" {
+			t.Errorf("expected a glob pattern not to be stat'd, got %v", issue)
+		}
+	}
+}
+
+func TestCheckThemeCollision(t *testing.T) {
+	conf := Conf{Envs: map[string]*Env{
+		"production": {Env: "production", Store: "shop.myshopify.com", Password: "pw", ThemeID: "123", Directory: "."},
+		"staging":    {Env: "staging", Store: "shop.myshopify.com", Password: "pw", ThemeID: "123", Directory: "."},
+	}}
+
+	issues := conf.Check()
+	found := false
+	for _, issue := range issues {
+		if issue.Severity == SeverityError && issue.Field == "theme_id" && issue.Env == "staging" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected two environments sharing a theme_id on the same store to collide, got %v", issues)
+	}
+}
+
+func TestCheckThemeCollisionIgnoresDifferentStores(t *testing.T) {
+	conf := Conf{Envs: map[string]*Env{
+		"production": {Env: "production", Store: "a.myshopify.com", Password: "pw", ThemeID: "123", Directory: "."},
+		"staging":    {Env: "staging", Store: "b.myshopify.com", Password: "pw", ThemeID: "123", Directory: "."},
+	}}
+
+	if issues := conf.Check(); len(issues) != 0 {
+		t.Errorf("expected the same theme_id on different stores not to collide, got %v", issues)
+	}
+}
+
+func TestCheckConfigOsEnvOnlyRequiredField(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themekit-checkconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := mustWriteFile(t, dir, "config.yml", `
+production:
+  store: shop.myshopify.com
+  theme_id: "123"
+  directory: .
+`)
+
+	os.Setenv("THEMEKIT_PASSWORD", "envpassword")
+	defer os.Unsetenv("THEMEKIT_PASSWORD")
+
+	var out bytes.Buffer
+	if ok := CheckConfig(path, &out); !ok {
+		t.Errorf("expected CheckConfig to pass when the required password is supplied via osEnv, got:\n%s", out.String())
+	}
+}
+
+func TestCheckConfigInterpolatesBeforeStatting(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themekit-checkconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := mustWriteFile(t, dir, "config.yml", `
+production:
+  store: shop.myshopify.com
+  password: pw
+  theme_id: "123"
+  directory: ${THEMEKIT_DIR}
+`)
+
+	os.Setenv("THEMEKIT_DIR", dir)
+	defer os.Unsetenv("THEMEKIT_DIR")
+
+	var out bytes.Buffer
+	if ok := CheckConfig(path, &out); !ok {
+		t.Errorf("expected CheckConfig to resolve ${THEMEKIT_DIR} before statting directory, got:\n%s", out.String())
+	}
+}
+
+func TestCheckConfigReportsLoadError(t *testing.T) {
+	var out bytes.Buffer
+	if ok := CheckConfig(filepath.Join(os.TempDir(), "themekit-does-not-exist.yml"), &out); ok {
+		t.Error("expected CheckConfig to fail when the config file cannot be found")
+	}
+	if out.Len() == 0 {
+		t.Error("expected CheckConfig to report the load error to out")
+	}
+}
@@ -1,21 +1,23 @@
 package env
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"encoding/json"
-	"github.com/caarlos0/env"
+	"github.com/BurntSushi/toml"
 	"gopkg.in/yaml.v1"
 )
 
 var (
-	supportedExts = []string{"yml", "yaml", "json"}
+	supportedExts = []string{"yml", "yaml", "json", "toml", "env"}
 	// ErrEnvDoesNotExist is returned when an environment that does not exist in the config is requested
 	ErrEnvDoesNotExist = errors.New("environment does not exist in this environments list")
 	// ErrEnvNotDefined is returned if the environment was found but had no config, this usually means poor conf
@@ -28,48 +30,221 @@ var (
 
 // Conf is a map of configurations to their environment name.
 type Conf struct {
-	Envs  map[string]*Env
-	osEnv Env
-	path  string
+	Envs    map[string]*Env
+	osEnv   Env
+	path    string
+	format  string
+	sources []string
 }
 
-// New will build a new blank config
+// New will build a new blank config. format is inferred from configPath's
+// extension, the same way Load infers it from the file it finds, so that
+// New followed by Set and Save writes back out in the format the path
+// implies even though no file has been read yet.
 func New(configPath string) Conf {
 	conf := Conf{
-		Envs:  make(map[string]*Env),
-		osEnv: Env{},
-		path:  configPath,
+		Envs:   make(map[string]*Env),
+		osEnv:  Env{},
+		path:   configPath,
+		format: strings.TrimPrefix(filepath.Ext(configPath), "."),
 	}
-	env.Parse(&conf.osEnv)
+	applyEnvOverrides(&conf.osEnv, os.LookupEnv)
 	return conf
 }
 
+// LoadOptions controls optional post-processing passes that Load can run
+// over a config after it has been unmarshalled.
+type LoadOptions struct {
+	// Interpolate expands ${VAR} / ${VAR:-default} / ${VAR:?err} references
+	// in string fields against the OS environment, via Resolve. Off by
+	// default so existing configs with literal "${" in them are unaffected.
+	Interpolate bool
+}
+
 // Load will read in the file from the configPath provided and
-// then unmarshal the data into conf.
-func Load(configPath string) (Conf, error) {
+// then unmarshal the data into conf. opts is optional; the zero value
+// runs no post-processing passes.
+func Load(configPath string, opts ...LoadOptions) (Conf, error) {
+	conf, err := loadConf(configPath, opts...)
+	if err != nil {
+		return conf, err
+	}
+	return conf, conf.validate()
+}
+
+// loadConf does the unmarshalling and optional interpolation Load does, but
+// stops short of validating: LoadWithLayers needs to mix in every overlay
+// before a missing required field is actually missing, so it loads its base
+// layer through here and validates once, at the end, itself.
+func loadConf(configPath string, opts ...LoadOptions) (Conf, error) {
 	conf := New(configPath)
 	path, ext, err := searchConfigPath(configPath)
 	if err != nil {
 		return conf, err
 	}
 
-	contents, err := ioutil.ReadFile(path)
-	if err == nil {
-		switch ext {
-		case "yml", "yaml":
-			if err = yaml.Unmarshal(contents, &conf.Envs); err != nil {
-				return conf, fmt.Errorf("Invalid yaml found while loading the config file: %v", err)
+	layer, err := unmarshalLayer(path, ext)
+	if err != nil {
+		return conf, err
+	}
+	conf.Envs = layer
+	conf.format = ext
+	conf.sources = []string{path}
+
+	if len(opts) > 0 && opts[0].Interpolate {
+		if err := Resolve(&conf, os.LookupEnv); err != nil {
+			return conf, err
+		}
+	}
+
+	return conf, nil
+}
+
+// LoadWithLayers reads each of paths in order and deep merges them into a
+// single Conf, per-environment and field-by-field, rather than replacing
+// one file's environments wholesale with the next. Later paths override
+// earlier ones; a path that does not exist is skipped so that optional
+// overlays (e.g. an untracked config.local.yml) are not required to be
+// present. The first path is required and resolved the same way Load
+// resolves its configPath. Validation runs once, after every layer has been
+// mixed in, so a required field supplied only by an overlay doesn't trip a
+// premature error on the base layer alone.
+func LoadWithLayers(paths ...string) (Conf, error) {
+	if len(paths) == 0 {
+		return Conf{}, errors.New("LoadWithLayers requires at least one path")
+	}
+
+	conf, err := loadConf(paths[0])
+	if err != nil {
+		return conf, err
+	}
+
+	for _, configPath := range paths[1:] {
+		layerPaths, err := layerPaths(configPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
 			}
-		case "json":
-			if err = json.Unmarshal(contents, &conf.Envs); err != nil {
-				return conf, fmt.Errorf("Invalid json found while loading the config file: %v", err)
+			return conf, err
+		}
+
+		for _, path := range layerPaths {
+			layer, err := unmarshalLayer(path, strings.TrimPrefix(filepath.Ext(path), "."))
+			if err != nil {
+				return conf, err
 			}
+			conf.mixinLayer(layer)
+			conf.sources = append(conf.sources, path)
 		}
 	}
 
 	return conf, conf.validate()
 }
 
+// mixinLayer deep merges layer into conf.Envs, field-by-field per
+// environment, so that an overlay only has to specify the fields it means
+// to override.
+func (c *Conf) mixinLayer(layer map[string]*Env) {
+	for name, overlay := range layer {
+		if overlay == nil {
+			continue
+		}
+		if existing, ok := c.Envs[name]; ok && existing != nil {
+			merged := mergeEnv(*existing, *overlay)
+			c.Envs[name] = &merged
+		} else {
+			c.Envs[name] = overlay
+		}
+	}
+}
+
+// layerPaths resolves configPath to the config file(s) it represents. A
+// plain file resolves the same way searchConfigPath does. A directory is
+// treated as a conf.d-style drop-in: every supported config file directly
+// inside it is applied in sorted order.
+func layerPaths(configPath string) ([]string, error) {
+	info, err := os.Stat(configPath)
+	if err != nil {
+		path, _, searchErr := searchConfigPath(configPath)
+		if searchErr != nil {
+			return nil, searchErr
+		}
+		return []string{path}, nil
+	}
+
+	if !info.IsDir() {
+		path, _, err := searchConfigPath(configPath)
+		if err != nil {
+			return nil, err
+		}
+		return []string{path}, nil
+	}
+
+	var matches []string
+	for _, ext := range supportedExts {
+		found, err := filepath.Glob(filepath.Join(configPath, "*."+ext))
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, found...)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// Sources returns the resolved stack of config files that were merged
+// together to build this Conf, in the order they were applied.
+func (c Conf) Sources() []string {
+	return c.sources
+}
+
+// unmarshalLayer reads a single config file at path and decodes it
+// according to ext, using the same format dispatch as Load.
+func unmarshalLayer(path, ext string) (map[string]*Env, error) {
+	layer := make(map[string]*Env)
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return layer, nil
+	}
+
+	switch ext {
+	case "yml", "yaml":
+		if err := yaml.Unmarshal(contents, &layer); err != nil {
+			return nil, fmt.Errorf("Invalid yaml found while loading the config file: %v", err)
+		}
+	case "json":
+		if err := json.Unmarshal(contents, &layer); err != nil {
+			return nil, fmt.Errorf("Invalid json found while loading the config file: %v", err)
+		}
+	case "toml":
+		if _, err := toml.Decode(string(contents), &layer); err != nil {
+			return nil, fmt.Errorf("Invalid toml found while loading the config file: %v", err)
+		}
+	case "env":
+		e := &Env{}
+		values := parseDotenv(contents)
+		if err := applyEnvOverrides(e, func(key string) (string, bool) {
+			v, ok := values[key]
+			return v, ok
+		}); err != nil {
+			return nil, fmt.Errorf("Invalid .env file found while loading the config file: %v", err)
+		}
+		layer[dotenvEnvName] = e
+	}
+
+	// newEnv sets Env on its result, but the unmarshal paths above build
+	// *Env values directly, so give each one its environment name here too;
+	// otherwise a validate() error for this layer renders with a blank name.
+	for name, e := range layer {
+		if e != nil {
+			e.Env = name
+		}
+	}
+
+	return layer, nil
+}
+
 // Set will set the environment value and then mixin any overrides passed in. The os
 // overrides and defaults will also be mixed into the new environment
 func (c *Conf) Set(name string, initial Env, overrides ...Env) (*Env, error) {
@@ -116,7 +291,9 @@ func (c Conf) validate() error {
 	return nil
 }
 
-// Save will write out the config to a file.
+// Save will write out the config to a file, in the format it was
+// originally loaded from, or for a config built with New, the format its
+// path's extension names (yaml for any extension other than json, toml or env).
 func (c Conf) Save() error {
 	f, err := c.file()
 	if err != nil {
@@ -126,6 +303,15 @@ func (c Conf) Save() error {
 	return c.save(f)
 }
 
+// SaveAs writes the config to path, inferring the output format from
+// path's extension rather than the format the config was loaded from.
+// This is how a config is converted from one format to another.
+func (c Conf) SaveAs(path string) error {
+	c.path = path
+	c.format = strings.TrimPrefix(filepath.Ext(path), ".")
+	return c.Save()
+}
+
 func (c Conf) save(w io.Writer) error {
 	// clear defaults before writing, we don't need to save defaults
 	for name, env := range c.Envs {
@@ -133,12 +319,7 @@ func (c Conf) save(w io.Writer) error {
 			delete(c.Envs, name)
 			continue
 		}
-		if env.Directory == Default.Directory {
-			env.Directory = ""
-		}
-		if env.Timeout == Default.Timeout {
-			env.Timeout = 0
-		}
+		elideDefaults(env)
 		c.Envs[name] = env
 	}
 
@@ -146,15 +327,34 @@ func (c Conf) save(w io.Writer) error {
 		return ErrNoEnvironmentsDefined
 	}
 
-	bytes, err := yaml.Marshal(c.Envs)
+	data, err := c.marshal()
 	if err != nil {
 		return err
 	}
 
-	_, err = w.Write(bytes)
+	_, err = w.Write(data)
 	return err
 }
 
+// marshal encodes c.Envs according to c.format, so that a config is always
+// written back out in the same format it was read in.
+func (c Conf) marshal() ([]byte, error) {
+	switch c.format {
+	case "json":
+		return json.MarshalIndent(c.Envs, "", "  ")
+	case "toml":
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(c.Envs); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case "env":
+		return marshalDotenv(c.Envs)
+	default:
+		return yaml.Marshal(c.Envs)
+	}
+}
+
 func (c Conf) file() (io.WriteCloser, error) {
 	return os.OpenFile(c.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 }
@@ -0,0 +1,123 @@
+package env
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func mustWriteFile(t *testing.T, dir, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("could not write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestLoadWithLayersConflictResolution(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themekit-layers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	base := mustWriteFile(t, dir, "config.yml", `
+production:
+  store: shop.myshopify.com
+  password: basepassword
+  theme_id: "111"
+  directory: dist
+`)
+	overlay := mustWriteFile(t, dir, "config.local.yml", `
+production:
+  password: localpassword
+`)
+
+	conf, err := LoadWithLayers(base, overlay)
+	if err != nil {
+		t.Fatalf("LoadWithLayers returned error: %v", err)
+	}
+
+	e, ok := conf.Envs["production"]
+	if !ok || e == nil {
+		t.Fatalf("expected production environment, got %#v", conf.Envs)
+	}
+	if e.Password != "localpassword" {
+		t.Errorf("expected overlay to win on password, got %q", e.Password)
+	}
+	if e.Store != "shop.myshopify.com" {
+		t.Errorf("expected base store to survive the merge, got %q", e.Store)
+	}
+	if e.Directory != "dist" {
+		t.Errorf("expected base directory to survive the merge, got %q", e.Directory)
+	}
+}
+
+func TestLoadWithLayersMissingOverlay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themekit-layers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	base := mustWriteFile(t, dir, "config.yml", `
+production:
+  store: shop.myshopify.com
+  password: basepassword
+  theme_id: "111"
+`)
+
+	conf, err := LoadWithLayers(base, filepath.Join(dir, "config.local.yml"))
+	if err != nil {
+		t.Fatalf("LoadWithLayers returned error for a missing overlay: %v", err)
+	}
+
+	if len(conf.Sources()) != 1 {
+		t.Errorf("expected only the base file to be recorded as a source, got %v", conf.Sources())
+	}
+
+	e := conf.Envs["production"]
+	if e == nil || e.Password != "basepassword" {
+		t.Errorf("expected base config to be untouched, got %#v", e)
+	}
+}
+
+func TestLoadWithLayersJSONAndYAMLMixing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themekit-layers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	base := mustWriteFile(t, dir, "config.yml", `
+production:
+  store: shop.myshopify.com
+  password: basepassword
+`)
+	overlay := mustWriteFile(t, dir, "config.local.json", `{
+  "production": {"theme_id": "123"}
+}`)
+
+	conf, err := LoadWithLayers(base, overlay)
+	if err != nil {
+		t.Fatalf("LoadWithLayers returned error: %v", err)
+	}
+
+	e := conf.Envs["production"]
+	if e == nil {
+		t.Fatal("expected production environment to exist")
+	}
+	if e.Store != "shop.myshopify.com" {
+		t.Errorf("expected yaml-sourced store to survive, got %q", e.Store)
+	}
+	if e.ThemeID != "123" {
+		t.Errorf("expected json-sourced theme_id to be merged in, got %q", e.ThemeID)
+	}
+
+	sources := conf.Sources()
+	if len(sources) != 2 || sources[0] != base || sources[1] != overlay {
+		t.Errorf("expected Sources() to report both layers in order, got %v", sources)
+	}
+}
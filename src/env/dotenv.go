@@ -0,0 +1,100 @@
+package env
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// parseDotenv reads a dotenv-style file (KEY=VALUE per line, blank lines
+// and "#" comments ignored, values optionally wrapped in quotes) into a
+// plain key/value map, ready to be fed to applyEnvOverrides.
+func parseDotenv(contents []byte) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.Trim(strings.TrimSpace(parts[1]), `"'`)
+		values[key] = value
+	}
+	return values
+}
+
+// dotenvEnvName is the environment name a loaded dotenv file's values are
+// flattened into. Dotenv has no notion of multiple environments, so
+// everything it defines lands in a single "default" environment; callers
+// wanting a different name can still override it afterwards with Conf.Set.
+//
+// Load and LoadWithLayers take no environment name for a dotenv file, so
+// its values are always stored under this name.
+const dotenvEnvName = "default"
+
+// marshalDotenv renders envs back out as a dotenv file. Dotenv has no
+// concept of multiple environments, so it errors rather than silently
+// dropping data if envs holds more than one; a caller converting a
+// multi-env config needs to Get the one environment it wants and save
+// that alone.
+func marshalDotenv(envs map[string]*Env) ([]byte, error) {
+	if len(envs) > 1 {
+		names := make([]string, 0, len(envs))
+		for name := range envs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("cannot save %d environments (%s) as .env: dotenv only supports a single environment", len(envs), strings.Join(names, ", "))
+	}
+
+	var e *Env
+	for _, candidate := range envs {
+		e = candidate
+	}
+	if e == nil {
+		return nil, nil
+	}
+
+	if dropped := untaggedNonZeroFields(e); len(dropped) > 0 {
+		return nil, fmt.Errorf("cannot save as .env: %s has no env: tag to round-trip through KEY=VALUE lines", strings.Join(dropped, ", "))
+	}
+
+	var buf bytes.Buffer
+	eachTaggedField(e, "env", func(field reflect.Value, name string) error {
+		if !isZeroValue(field) {
+			fmt.Fprintf(&buf, "%s=%v\n", name, field.Interface())
+		}
+		return nil
+	})
+	return buf.Bytes(), nil
+}
+
+// untaggedNonZeroFields returns the config key (see fieldKey) of every
+// field on e that has a value set but carries no `env:"..."` tag, and so
+// would otherwise be silently dropped by marshalDotenv's eachTaggedField
+// walk over "env".
+func untaggedNonZeroFields(e *Env) []string {
+	var dropped []string
+	v := reflect.ValueOf(e).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Name == "Env" {
+			continue
+		}
+		if _, ok := field.Tag.Lookup("env"); ok {
+			continue
+		}
+		if isZeroValue(v.Field(i)) {
+			continue
+		}
+		dropped = append(dropped, fieldKey(field))
+	}
+	return dropped
+}
@@ -0,0 +1,87 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Default holds the baseline values for every `default:`-tagged field on
+// Env. It is mixed into every environment before any file or override is
+// applied, and is what save compares against to decide which fields can be
+// omitted when writing a config back out.
+var Default = mustApplyDefaults(Env{})
+
+// Env is the configuration for a single environment. Each exported field
+// maps to a key in a themekit config file. A zero field is filled from its
+// `default` tag by applyDefaults, then overridden from the OS environment
+// variable named by its `env` tag by applyEnvOverrides, and `required`
+// fields are checked once the environment's name is known, in validate.
+type Env struct {
+	Env         string        `yaml:"-" json:"-" toml:"-"`
+	Store       string        `yaml:"store,omitempty" json:"store,omitempty" toml:"store" required:"true" env:"THEMEKIT_STORE"`
+	Password    string        `yaml:"password,omitempty" json:"password,omitempty" toml:"password" required:"true" env:"THEMEKIT_PASSWORD"`
+	ThemeID     string        `yaml:"theme_id,omitempty" json:"theme_id,omitempty" toml:"theme_id" required:"true" env:"THEMEKIT_THEME_ID"`
+	Directory   string        `yaml:"directory,omitempty" json:"directory,omitempty" toml:"directory,omitempty" default:"." env:"THEMEKIT_DIRECTORY"`
+	Timeout     time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty" toml:"timeout,omitempty" default:"30s" env:"THEMEKIT_TIMEOUT"`
+	Proxy       string        `yaml:"proxy,omitempty" json:"proxy,omitempty" toml:"proxy,omitempty" env:"THEMEKIT_PROXY"`
+	IgnoreFiles []string      `yaml:"ignore_files,omitempty" json:"ignore_files,omitempty" toml:"ignore_files,omitempty"`
+}
+
+// newEnv builds a new *Env for name, mixing initial on top of Default and
+// then layering each of overrides on top in turn, last one wins.
+func newEnv(name string, initial Env, overrides ...Env) (*Env, error) {
+	merged := mergeEnv(Default, initial)
+	for _, override := range overrides {
+		merged = mergeEnv(merged, override)
+	}
+	merged.Env = name
+	return &merged, nil
+}
+
+// mergeEnv layers src on top of dst, any field that is non-zero in src
+// wins over the value already present in dst.
+func mergeEnv(dst, src Env) Env {
+	if src.Store != "" {
+		dst.Store = src.Store
+	}
+	if src.Password != "" {
+		dst.Password = src.Password
+	}
+	if src.ThemeID != "" {
+		dst.ThemeID = src.ThemeID
+	}
+	if src.Directory != "" {
+		dst.Directory = src.Directory
+	}
+	if src.Timeout != 0 {
+		dst.Timeout = src.Timeout
+	}
+	if src.Proxy != "" {
+		dst.Proxy = src.Proxy
+	}
+	if len(src.IgnoreFiles) > 0 {
+		dst.IgnoreFiles = src.IgnoreFiles
+	}
+	return dst
+}
+
+// validate checks that every field tagged `required:"true"` on Env is set.
+func (e Env) validate() error {
+	missing := missingRequiredFields(e)
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("[%s] missing required fields: %s", e.Env, strings.Join(missing, ", "))
+}
+
+// mustApplyDefaults builds an Env with every `default:"..."` tagged field
+// filled in. It is only used once, to build the package-level Default, so a
+// malformed default tag is a programmer error and panics rather than
+// propagating.
+func mustApplyDefaults(e Env) Env {
+	if err := applyDefaults(&e); err != nil {
+		panic(fmt.Sprintf("env: %v", err))
+	}
+	return e
+}
@@ -0,0 +1,179 @@
+package env
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatRoundTrip(t *testing.T) {
+	for _, format := range []string{"yml", "json", "toml"} {
+		format := format
+		t.Run(format, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "themekit-format")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "config."+format)
+			conf := New(path)
+			if _, err := conf.Set("production", Env{
+				Store:    "shop.myshopify.com",
+				Password: "filepassword",
+				ThemeID:  "123",
+			}); err != nil {
+				t.Fatalf("Set returned error: %v", err)
+			}
+			if err := conf.Save(); err != nil {
+				t.Fatalf("Save returned error: %v", err)
+			}
+
+			loaded, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load returned error: %v", err)
+			}
+			saved := loaded.Envs["production"]
+			if saved == nil {
+				t.Fatal("expected production environment to round-trip")
+			}
+			if saved.Directory != "" || saved.Timeout != 0 {
+				t.Errorf("expected default-valued fields to be elided from the saved file, got directory=%q timeout=%v", saved.Directory, saved.Timeout)
+			}
+
+			resolved, err := loaded.Get("production")
+			if err != nil {
+				t.Fatalf("Get returned error: %v", err)
+			}
+			if resolved.Directory != Default.Directory || resolved.Timeout != Default.Timeout {
+				t.Errorf("expected Get to refill elided fields with Default, got directory=%q timeout=%v", resolved.Directory, resolved.Timeout)
+			}
+			if resolved.Store != "shop.myshopify.com" || resolved.Password != "filepassword" || resolved.ThemeID != "123" {
+				t.Errorf("round trip lost an explicit field: %#v", resolved)
+			}
+
+			os.Setenv("THEMEKIT_PASSWORD", "envpassword")
+			defer os.Unsetenv("THEMEKIT_PASSWORD")
+
+			withEnvOverride, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			overridden, err := withEnvOverride.Get("production")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if overridden.Password != "envpassword" {
+				t.Errorf("expected THEMEKIT_PASSWORD to take precedence over the saved password, got %q", overridden.Password)
+			}
+		})
+	}
+}
+
+func TestDotenvRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themekit-dotenv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".env")
+	mustWriteFile(t, dir, ".env", "THEMEKIT_STORE=shop.myshopify.com\nTHEMEKIT_PASSWORD=filepassword\nTHEMEKIT_THEME_ID=123\n")
+
+	conf, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	e := conf.Envs[dotenvEnvName]
+	if e == nil || e.Store != "shop.myshopify.com" || e.ThemeID != "123" {
+		t.Fatalf("expected dotenv values to flatten into the %q environment, got %#v", dotenvEnvName, e)
+	}
+
+	os.Setenv("THEMEKIT_PASSWORD", "envpassword")
+	defer os.Unsetenv("THEMEKIT_PASSWORD")
+
+	withEnvOverride, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := withEnvOverride.Get(dotenvEnvName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Password != "envpassword" {
+		t.Errorf("expected THEMEKIT_PASSWORD to take precedence over the dotenv file's password, got %q", resolved.Password)
+	}
+}
+
+func TestDotenvSaveRejectsUntaggedField(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themekit-dotenv-untagged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := New(filepath.Join(dir, ".env"))
+	if _, err := conf.Set(dotenvEnvName, Env{
+		Store:       "shop.myshopify.com",
+		Password:    "pw",
+		ThemeID:     "123",
+		IgnoreFiles: []string{"a.txt", "b.txt"},
+	}); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+
+	if err := conf.Save(); err == nil {
+		t.Fatal("expected Save to a .env path to fail rather than silently drop ignore_files")
+	}
+}
+
+func TestSaveAsConvertsFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themekit-saveas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := New(filepath.Join(dir, "config.yml"))
+	if _, err := conf.Set("production", Env{Store: "shop.myshopify.com", Password: "pw", ThemeID: "123"}); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if err := conf.Save(); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	jsonPath := filepath.Join(dir, "config.json")
+	if err := conf.SaveAs(jsonPath); err != nil {
+		t.Fatalf("SaveAs returned error: %v", err)
+	}
+
+	loaded, err := Load(jsonPath)
+	if err != nil {
+		t.Fatalf("Load of the converted file returned error: %v", err)
+	}
+	if e := loaded.Envs["production"]; e == nil || e.Store != "shop.myshopify.com" {
+		t.Errorf("expected SaveAs to produce a loadable json config, got %#v", e)
+	}
+}
+
+func TestSaveAsDotenvRejectsMultipleEnvironments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "themekit-saveas-dotenv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := New(filepath.Join(dir, "config.yml"))
+	if _, err := conf.Set("production", Env{Store: "a.myshopify.com", Password: "pw1", ThemeID: "1"}); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+	if _, err := conf.Set("staging", Env{Store: "b.myshopify.com", Password: "pw2", ThemeID: "2"}); err != nil {
+		t.Fatalf("Set returned error: %v", err)
+	}
+
+	err = conf.SaveAs(filepath.Join(dir, ".env"))
+	if err == nil {
+		t.Fatal("expected SaveAs to a .env path to fail when more than one environment is defined")
+	}
+}
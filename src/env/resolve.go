@@ -0,0 +1,89 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// interpolationPattern matches ${VAR}, ${VAR:-default} and ${VAR:?err}
+// references, mirroring the compose-spec style of variable interpolation.
+var interpolationPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)((:-|:\?)([^}]*))?\}`)
+
+// Resolve walks every *Env in conf and expands ${VAR} / ${VAR:-default} /
+// ${VAR:?err} references in its string fields against lookup. ${VAR} and
+// ${VAR:-default} fall back to "" or default when VAR is unset; ${VAR:?err}
+// fails the whole resolve with err, so a required secret is never silently
+// left blank. lookup is normally os.LookupEnv; tests can inject their own.
+func Resolve(conf *Conf, lookup func(string) (string, bool)) error {
+	for name, e := range conf.Envs {
+		if e == nil {
+			continue
+		}
+		if err := resolveEnv(e, lookup); err != nil {
+			return fmt.Errorf("[%s] %v", name, err)
+		}
+	}
+	return nil
+}
+
+// resolveEnv interpolates every string field of e in place, except Env
+// itself, which is the environment's name rather than user-supplied data.
+func resolveEnv(e *Env, lookup func(string) (string, bool)) error {
+	v := reflect.ValueOf(e).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Name == "Env" {
+			continue
+		}
+		field := v.Field(i)
+		if field.Kind() != reflect.String {
+			continue
+		}
+		resolved, err := interpolate(field.String(), lookup)
+		if err != nil {
+			return fmt.Errorf("%s: %v", t.Field(i).Name, err)
+		}
+		field.SetString(resolved)
+	}
+	return nil
+}
+
+// interpolate expands every ${...} reference in value using lookup.
+func interpolate(value string, lookup func(string) (string, bool)) (string, error) {
+	var resolveErr error
+	result := interpolationPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if resolveErr != nil {
+			return match
+		}
+		groups := interpolationPattern.FindStringSubmatch(match)
+		name, op, arg := groups[1], groups[3], groups[4]
+
+		raw, ok := lookup(name)
+		if ok && raw == "" && (op == ":-" || op == ":?") {
+			// compose-spec's colon forms treat an explicitly-set-but-empty
+			// variable the same as unset.
+			ok = false
+		}
+		if ok {
+			return raw
+		}
+		switch op {
+		case ":-":
+			return arg
+		case ":?":
+			msg := arg
+			if msg == "" {
+				msg = "required variable is not set"
+			}
+			resolveErr = fmt.Errorf("%s: %s", name, msg)
+			return match
+		default:
+			return ""
+		}
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return result, nil
+}
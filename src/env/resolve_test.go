@@ -0,0 +1,89 @@
+package env
+
+import "testing"
+
+func lookupFrom(values map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := values[name]
+		return v, ok
+	}
+}
+
+func TestInterpolateUnset(t *testing.T) {
+	resolved, err := interpolate("${MISSING}", lookupFrom(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved != "" {
+		t.Errorf("expected an unset ${VAR} to resolve to empty string, got %q", resolved)
+	}
+}
+
+func TestInterpolateDefault(t *testing.T) {
+	resolved, err := interpolate("${MISSING:-fallback}", lookupFrom(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved != "fallback" {
+		t.Errorf("expected ${VAR:-default} to fall back when unset, got %q", resolved)
+	}
+
+	resolved, err = interpolate("${SET:-fallback}", lookupFrom(map[string]string{"SET": "value"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved != "value" {
+		t.Errorf("expected ${VAR:-default} to use the set value, got %q", resolved)
+	}
+
+	resolved, err = interpolate("${EMPTY:-fallback}", lookupFrom(map[string]string{"EMPTY": ""}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved != "fallback" {
+		t.Errorf("expected ${VAR:-default} to fall back when set but empty, got %q", resolved)
+	}
+}
+
+func TestInterpolateRequiredError(t *testing.T) {
+	_, err := interpolate("${MISSING:?password required}", lookupFrom(nil))
+	if err == nil {
+		t.Fatal("expected ${VAR:?err} to error when the variable is unset")
+	}
+
+	resolved, err := interpolate("${SET:?password required}", lookupFrom(map[string]string{"SET": "value"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved != "value" {
+		t.Errorf("expected ${VAR:?err} to use the set value, got %q", resolved)
+	}
+}
+
+func TestResolveExpandsEnvFields(t *testing.T) {
+	conf := Conf{Envs: map[string]*Env{
+		"production": {
+			Env:       "production",
+			Store:     "shop.myshopify.com",
+			Password:  "${SHOPIFY_PASSWORD:?password required}",
+			ThemeID:   "123",
+			Directory: "${SHOPIFY_DIR:-dist}",
+		},
+	}}
+
+	if err := Resolve(&conf, lookupFrom(map[string]string{"SHOPIFY_PASSWORD": "secret"})); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	e := conf.Envs["production"]
+	if e.Password != "secret" {
+		t.Errorf("expected password to be resolved, got %q", e.Password)
+	}
+	if e.Directory != "dist" {
+		t.Errorf("expected directory to fall back to default, got %q", e.Directory)
+	}
+
+	conf.Envs["production"].Password = "${SHOPIFY_PASSWORD:?password required}"
+	if err := Resolve(&conf, lookupFrom(nil)); err == nil {
+		t.Fatal("expected Resolve to error when a required variable is missing")
+	}
+}
@@ -0,0 +1,127 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// applyDefaults fills every zero-valued field on e that carries a
+// `default:"..."` struct tag, parsed according to the field's type.
+func applyDefaults(e *Env) error {
+	return eachTaggedField(e, "default", func(field reflect.Value, raw string) error {
+		if !isZeroValue(field) {
+			return nil
+		}
+		return setFieldFromString(field, raw)
+	})
+}
+
+// applyEnvOverrides fills every field on e that carries an `env:"..."`
+// struct tag with the value lookup returns for that name, if any.
+func applyEnvOverrides(e *Env, lookup func(string) (string, bool)) error {
+	return eachTaggedField(e, "env", func(field reflect.Value, name string) error {
+		raw, ok := lookup(name)
+		if !ok {
+			return nil
+		}
+		return setFieldFromString(field, raw)
+	})
+}
+
+// elideDefaults zeros every field on e that still equals the value
+// declared in its `default:"..."` tag, so that Conf.save doesn't persist
+// values the user never actually asked for.
+func elideDefaults(e *Env) {
+	v := reflect.ValueOf(e).Elem()
+	dv := reflect.ValueOf(Default)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("default"); !ok {
+			continue
+		}
+		field := v.Field(i)
+		if reflect.DeepEqual(field.Interface(), dv.Field(i).Interface()) {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+}
+
+// missingRequiredFields returns the config key (its yaml tag name, falling
+// back to the Go field name) of every field tagged `required:"true"` on e
+// that is still zero.
+func missingRequiredFields(e Env) []string {
+	missing := []string{}
+	v := reflect.ValueOf(e)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Tag.Get("required") != "true" {
+			continue
+		}
+		if isZeroValue(v.Field(i)) {
+			missing = append(missing, fieldKey(field))
+		}
+	}
+	return missing
+}
+
+// eachTaggedField walks the exported fields of e, calling fn with the
+// field's reflect.Value and the value of the tag named tagName whenever
+// that tag is present.
+func eachTaggedField(e *Env, tagName string, fn func(field reflect.Value, tagValue string) error) error {
+	v := reflect.ValueOf(e).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tagValue, ok := t.Field(i).Tag.Lookup(tagName)
+		if !ok {
+			continue
+		}
+		if err := fn(v.Field(i), tagValue); err != nil {
+			return fmt.Errorf("%s: %v", t.Field(i).Name, err)
+		}
+	}
+	return nil
+}
+
+// fieldKey returns the name a field is known by in a config file: its yaml
+// tag name if it has one, otherwise its Go field name.
+func fieldKey(field reflect.StructField) string {
+	yamlTag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if yamlTag != "" && yamlTag != "-" {
+		return yamlTag
+	}
+	return field.Name
+}
+
+// isZeroValue reports whether field holds its type's zero value.
+func isZeroValue(field reflect.Value) bool {
+	return reflect.DeepEqual(field.Interface(), reflect.Zero(field.Type()).Interface())
+}
+
+// setFieldFromString parses raw according to field's type and sets it.
+func setFieldFromString(field reflect.Value, raw string) error {
+	switch {
+	case field.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.String:
+		field.SetString(raw)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		field.Set(reflect.ValueOf(strings.Split(raw, ",")))
+	default:
+		return fmt.Errorf("unsupported field kind %s", field.Kind())
+	}
+	return nil
+}